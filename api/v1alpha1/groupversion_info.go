@@ -0,0 +1,34 @@
+// Package v1alpha1 contains API Schema definitions for the operator's
+// apps v1alpha1 API group.
+// +kubebuilder:object:generate=true
+// +groupName=apps.openshift.io
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+var (
+	// GroupVersion is group version used to register these objects.
+	GroupVersion = schema.GroupVersion{Group: "apps.openshift.io", Version: "v1alpha1"}
+
+	// SchemeBuilder is used to add go types to the GroupVersionKind scheme.
+	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+
+	// AddToScheme adds the types in this group-version to the given scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+)
+
+var (
+	// GroupVersionKind identifies the ServiceBinding CRD itself, used by
+	// sbrRequestMapper to recognize ServiceBinding events.
+	GroupVersionKind = GroupVersion.WithKind("ServiceBinding")
+
+	// GroupVersionResource identifies the ServiceBinding CRD's REST resource.
+	GroupVersionResource = GroupVersion.WithResource("servicebindings")
+)
+
+func init() {
+	SchemeBuilder.Register(&ServiceBinding{}, &ServiceBindingList{})
+}
@@ -0,0 +1,176 @@
+package v1alpha1
+
+import (
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// errGroupVersionResourceUnresolved is returned by Referable implementations that
+// only carry a GVK; callers fall back to resolving the GVR through a RESTMapper.
+var errGroupVersionResourceUnresolved = errors.New("GroupVersionResource is not known, resolve it through a RESTMapper")
+
+// Mapping is a single custom environment variable entry in
+// ServiceBindingSpec.Mappings. Value may contain field reference placeholders
+// (e.g. "{{ service:db#status.host }}") that get resolved against a
+// referenced service before being written into the target Secret.
+type Mapping struct {
+	// Name is the key the resolved value is written under in the target Secret.
+	Name string `json:"name"`
+	// Value is a literal value, or one containing field reference placeholders.
+	Value string `json:"value"`
+}
+
+// Service is a reference to an existing Kubernetes or custom resource that a
+// ServiceBinding projects into its target Secret.
+type Service struct {
+	// APIVersion of the referenced service, e.g. "v1" or "servicecatalog.k8s.io/v1beta1".
+	APIVersion string `json:"apiVersion,omitempty"`
+	// Kind of the referenced service, e.g. "Secret" or "ServiceBinding".
+	Kind string `json:"kind,omitempty"`
+
+	// Namespace of the referenced object. Defaults to the ServiceBinding's own
+	// namespace when empty.
+	Namespace string `json:"namespace,omitempty"`
+	// Name of the referenced object. When LabelSelector is set, Name does not
+	// select any object; it only prefixes the target Secret keys written for
+	// the matched objects.
+	Name string `json:"name,omitempty"`
+
+	// LabelSelector, when set, causes this entry to match every object of the
+	// declared GVK in Namespace (the ServiceBinding's namespace when empty)
+	// whose labels satisfy the selector, instead of the single object
+	// identified by Name.
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// EnvVarMapping declares additional custom environment variables, resolved
+	// the same way as ServiceBindingSpec.Mappings, but scoped to this service.
+	// +optional
+	EnvVarMapping map[string]string `json:"envVarMapping,omitempty"`
+}
+
+// GroupVersionKind returns the GVK the Service's APIVersion and Kind describe.
+func (s *Service) GroupVersionKind() (*schema.GroupVersionKind, error) {
+	gv, err := schema.ParseGroupVersion(s.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+	gvk := gv.WithKind(s.Kind)
+	return &gvk, nil
+}
+
+// GroupVersionResource is not known from a Service reference alone; callers
+// resolve it through a RESTMapper via GroupVersionKind instead.
+func (s *Service) GroupVersionResource() (*schema.GroupVersionResource, error) {
+	return nil, errGroupVersionResourceUnresolved
+}
+
+// Application is a reference to the workload a ServiceBinding injects
+// bindings into.
+type Application struct {
+	// APIVersion of the application, e.g. "apps/v1".
+	APIVersion string `json:"apiVersion,omitempty"`
+	// Kind of the application, e.g. "Deployment".
+	Kind string `json:"kind,omitempty"`
+
+	// Name of the application. When empty, every object of the declared GVK,
+	// in any namespace, is considered a match, unless LabelSelector is set.
+	Name string `json:"name,omitempty"`
+
+	// LabelSelector, when set, causes every object of the declared GVK in the
+	// ServiceBinding's namespace whose labels satisfy the selector to be
+	// considered the application, instead of the single object identified by
+	// Name.
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+}
+
+// GroupVersionKind returns the GVK the Application's APIVersion and Kind describe.
+func (a *Application) GroupVersionKind() (*schema.GroupVersionKind, error) {
+	gv, err := schema.ParseGroupVersion(a.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+	gvk := gv.WithKind(a.Kind)
+	return &gvk, nil
+}
+
+// GroupVersionResource is not known from an Application reference alone;
+// callers resolve it through a RESTMapper via GroupVersionKind instead.
+func (a *Application) GroupVersionResource() (*schema.GroupVersionResource, error) {
+	return nil, errGroupVersionResourceUnresolved
+}
+
+// ServiceBindingSpec defines the desired state of a ServiceBinding.
+type ServiceBindingSpec struct {
+	// Application is the workload bindings are injected into.
+	// +optional
+	Application *Application `json:"application,omitempty"`
+
+	// Services lists the services this ServiceBinding projects into the
+	// target Secret.
+	Services []Service `json:"services"`
+
+	// Mappings lists additional custom environment variables projected into
+	// the target Secret, possibly resolved from field reference placeholders.
+	// +optional
+	Mappings []Mapping `json:"mappings,omitempty"`
+
+	// DetectBindingResources is accepted and stored, but the controller does
+	// not read it: no bindable data is detected in the referenced services'
+	// CRDs.
+	// +optional
+	DetectBindingResources bool `json:"detectBindingResources,omitempty"`
+}
+
+// Condition describes one aspect of a ServiceBinding's observed state.
+type Condition struct {
+	Type               string                 `json:"type"`
+	Status             corev1.ConditionStatus `json:"status"`
+	Reason             string                 `json:"reason,omitempty"`
+	Message            string                 `json:"message,omitempty"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime,omitempty"`
+}
+
+// ServiceBindingStatus defines the observed state of a ServiceBinding.
+type ServiceBindingStatus struct {
+	// Secret is the name of the Secret this ServiceBinding projects bindings into.
+	Secret string `json:"secret,omitempty"`
+
+	// Conditions describes the observed state of the ServiceBinding, including
+	// Ready=False when a cyclic field reference is detected. A reference that
+	// does not resolve yet leaves the conditions unchanged and the
+	// ServiceBinding is requeued.
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// BindingReady is the condition type signalling whether a ServiceBinding
+	// has successfully projected its bindings into its target Secret.
+	BindingReady = "Ready"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// ServiceBinding is the Schema for the servicebindings API.
+type ServiceBinding struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ServiceBindingSpec   `json:"spec,omitempty"`
+	Status ServiceBindingStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ServiceBindingList contains a list of ServiceBinding.
+type ServiceBindingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ServiceBinding `json:"items"`
+}
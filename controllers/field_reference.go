@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/jsonpath"
+
+	"github.com/redhat-developer/service-binding-operator/api/v1alpha1"
+)
+
+// fieldReferencePattern matches Smith-style placeholders of the form
+// "{{ service:<serviceRef>#<jsonpath> }}" and "{{ bindsecret:<serviceRef>#<key> }}"
+// that may appear in spec.mappings[].value and spec.services[].envVarMapping.
+var fieldReferencePattern = regexp.MustCompile(`{{\s*(service|bindsecret):([^#{}]+)#([^{}]+?)\s*}}`)
+
+// fieldReferenceKind distinguishes a reference to a service's live object from a
+// reference to its Service-Catalog-style binding Secret.
+type fieldReferenceKind string
+
+const (
+	fieldReferenceService    fieldReferenceKind = "service"
+	fieldReferenceBindSecret fieldReferenceKind = "bindsecret"
+)
+
+// fieldReference is a single placeholder found in a SBR's mappings or
+// envVarMapping, not yet resolved against a live object.
+type fieldReference struct {
+	Kind       fieldReferenceKind
+	ServiceRef string
+	Path       string
+	// Raw is the exact "{{ ... }}" text matched, so callers can substitute
+	// the resolved value back into the surrounding string.
+	Raw string
+}
+
+// parseFieldReferences extracts every field reference placeholder from s.
+func parseFieldReferences(s string) []fieldReference {
+	matches := fieldReferencePattern.FindAllStringSubmatch(s, -1)
+	refs := make([]fieldReference, 0, len(matches))
+	for _, m := range matches {
+		refs = append(refs, fieldReference{
+			Kind:       fieldReferenceKind(m[1]),
+			ServiceRef: m[2],
+			Path:       m[3],
+			Raw:        m[0],
+		})
+	}
+	return refs
+}
+
+// fieldReferencesInSBR collects every field reference declared across sbr's
+// custom mappings and per-service envVarMapping entries.
+func fieldReferencesInSBR(sbr *v1alpha1.ServiceBinding) []fieldReference {
+	var refs []fieldReference
+
+	for _, mapping := range sbr.Spec.Mappings {
+		refs = append(refs, parseFieldReferences(mapping.Value)...)
+	}
+
+	for _, svc := range sbr.Spec.Services {
+		if svc.EnvVarMapping == nil {
+			continue
+		}
+		for _, v := range svc.EnvVarMapping {
+			refs = append(refs, parseFieldReferences(v)...)
+		}
+	}
+
+	return refs
+}
+
+// resolveFieldReferenceService looks up the v1alpha1.Service that ref.ServiceRef
+// identifies among sbr's declared services, matching by Service.Name in the
+// absence of a dedicated alias field.
+func resolveFieldReferenceService(sbr *v1alpha1.ServiceBinding, ref fieldReference) (*v1alpha1.Service, error) {
+	for i := range sbr.Spec.Services {
+		if sbr.Spec.Services[i].Name == ref.ServiceRef {
+			return &sbr.Spec.Services[i], nil
+		}
+	}
+	return nil, fmt.Errorf("field reference %q does not match any declared service", ref.ServiceRef)
+}
+
+// hasCyclicFieldReference reports whether resolving sbr's field references
+// would eventually require resolving sbr itself again, following bindsecret
+// references through other ServiceBindings' owned secrets. byName looks up a
+// ServiceBinding's field references by its namespaced name, and
+// resolveSecretOwner maps a bindsecret reference to the ServiceBinding whose
+// Status.Secret it points at.
+// resolveSecretOwner is given the namespaced name of the ServiceBinding
+// currently being visited alongside the serviceRef to resolve, since each
+// ServiceBinding in the chain picks its own Service aliases and a bindsecret
+// reference must be resolved against the ServiceBinding that declared it, not
+// against the one the walk started from.
+func hasCyclicFieldReference(
+	start types.NamespacedName,
+	byName map[types.NamespacedName][]fieldReference,
+	resolveSecretOwner func(visiting types.NamespacedName, serviceRef string) (types.NamespacedName, bool),
+) bool {
+	visited := make(map[types.NamespacedName]bool)
+
+	var visit func(name types.NamespacedName) bool
+	visit = func(name types.NamespacedName) bool {
+		if name == start && visited[name] {
+			return true
+		}
+		if visited[name] {
+			return false
+		}
+		visited[name] = true
+
+		for _, ref := range byName[name] {
+			if ref.Kind != fieldReferenceBindSecret {
+				continue
+			}
+			owner, ok := resolveSecretOwner(name, ref.ServiceRef)
+			if !ok {
+				continue
+			}
+			if owner == start {
+				return true
+			}
+			if visit(owner) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return visit(start)
+}
+
+// resolveFieldReference fetches the live value a single field reference
+// placeholder points at: for fieldReferenceService, the result of evaluating
+// ref.Path as a JSONPath against the referenced service's live object; for
+// fieldReferenceBindSecret, the value of the key ref.Path in another
+// ServiceBinding's projected Secret, addressed directly by Service.Name.
+func (r *ServiceBindingReconciler) resolveFieldReference(sbr *v1alpha1.ServiceBinding, ref fieldReference) (string, error) {
+	svc, err := resolveFieldReferenceService(sbr, ref)
+	if err != nil {
+		return "", err
+	}
+	namespace := svc.Namespace
+	if namespace == "" {
+		namespace = sbr.Namespace
+	}
+
+	if ref.Kind == fieldReferenceBindSecret {
+		secret := &corev1.Secret{}
+		if err := r.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: svc.Name}, secret); err != nil {
+			return "", err
+		}
+		value, ok := secret.Data[ref.Path]
+		if !ok {
+			return "", fmt.Errorf("key %q not found in Secret %s/%s referenced by %q", ref.Path, namespace, svc.Name, ref.ServiceRef)
+		}
+		return string(value), nil
+	}
+
+	gvk, err := r.typeLookup.KindForReferable(svc)
+	if err != nil {
+		return "", err
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(*gvk)
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: svc.Name}, obj); err != nil {
+		return "", err
+	}
+
+	jp := jsonpath.New(ref.ServiceRef).AllowMissingKeys(false)
+	if err := jp.Parse("{." + ref.Path + "}"); err != nil {
+		return "", fmt.Errorf("parsing JSONPath %q: %w", ref.Path, err)
+	}
+	var buf bytes.Buffer
+	if err := jp.Execute(&buf, obj.UnstructuredContent()); err != nil {
+		return "", fmt.Errorf("evaluating JSONPath %q against %s/%s: %w", ref.Path, namespace, svc.Name, err)
+	}
+	return buf.String(), nil
+}
+
+// resolveMappingValue substitutes every field reference placeholder in value
+// with its live resolved value, returning the composed result. A value with
+// no placeholders is returned unchanged.
+func (r *ServiceBindingReconciler) resolveMappingValue(sbr *v1alpha1.ServiceBinding, value string) (string, error) {
+	refs := parseFieldReferences(value)
+	if len(refs) == 0 {
+		return value, nil
+	}
+
+	resolved := value
+	for _, ref := range refs {
+		v, err := r.resolveFieldReference(sbr, ref)
+		if err != nil {
+			return "", fmt.Errorf("resolving field reference %q: %w", ref.Raw, err)
+		}
+		resolved = strings.Replace(resolved, ref.Raw, v, 1)
+	}
+	return resolved, nil
+}
+
+// resolveMappings evaluates every literal and field-reference-bearing value in
+// sbr.Spec.Mappings and each declared Service's EnvVarMapping, returning the
+// Secret data entries they project. A value whose field reference can't yet be
+// resolved (the referenced object or Secret key doesn't exist) surfaces as an
+// error, so Reconcile can requeue until it does.
+func (r *ServiceBindingReconciler) resolveMappings(sbr *v1alpha1.ServiceBinding) (map[string][]byte, error) {
+	data := make(map[string][]byte)
+
+	for _, mapping := range sbr.Spec.Mappings {
+		value, err := r.resolveMappingValue(sbr, mapping.Value)
+		if err != nil {
+			return nil, err
+		}
+		data[mapping.Name] = []byte(value)
+	}
+
+	for i := range sbr.Spec.Services {
+		for key, raw := range sbr.Spec.Services[i].EnvVarMapping {
+			value, err := r.resolveMappingValue(sbr, raw)
+			if err != nil {
+				return nil, err
+			}
+			data[key] = []byte(value)
+		}
+	}
+
+	return data, nil
+}
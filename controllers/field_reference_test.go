@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/redhat-developer/service-binding-operator/api/v1alpha1"
+)
+
+func TestParseFieldReferences(t *testing.T) {
+	refs := parseFieldReferences("prefix {{ service:db#status.host }} and {{ bindsecret:other#password }} suffix")
+	if len(refs) != 2 {
+		t.Fatalf("expected 2 field references, got %d: %+v", len(refs), refs)
+	}
+	if refs[0].Kind != fieldReferenceService || refs[0].ServiceRef != "db" || refs[0].Path != "status.host" {
+		t.Fatalf("unexpected first reference: %+v", refs[0])
+	}
+	if refs[1].Kind != fieldReferenceBindSecret || refs[1].ServiceRef != "other" || refs[1].Path != "password" {
+		t.Fatalf("unexpected second reference: %+v", refs[1])
+	}
+}
+
+func TestHasCyclicFieldReferenceDetectsCycle(t *testing.T) {
+	a := types.NamespacedName{Namespace: "ns", Name: "a"}
+	b := types.NamespacedName{Namespace: "ns", Name: "b"}
+
+	// a references b's owned secret, and b references a's owned secret back.
+	byName := map[types.NamespacedName][]fieldReference{
+		a: {{Kind: fieldReferenceBindSecret, ServiceRef: "b-service", Path: "password"}},
+		b: {{Kind: fieldReferenceBindSecret, ServiceRef: "a-service", Path: "password"}},
+	}
+	resolveSecretOwner := func(visiting types.NamespacedName, serviceRef string) (types.NamespacedName, bool) {
+		switch serviceRef {
+		case "b-service":
+			return b, true
+		case "a-service":
+			return a, true
+		default:
+			return types.NamespacedName{}, false
+		}
+	}
+
+	if !hasCyclicFieldReference(a, byName, resolveSecretOwner) {
+		t.Fatal("expected a cycle to be detected")
+	}
+}
+
+// TestHasCyclicFieldReferenceDetectsCycleWithPerSBRAliases covers the realistic
+// case where each ServiceBinding in the cycle picks its own alias for the
+// other's Service: resolveSecretOwner must be evaluated against whichever
+// ServiceBinding is currently being visited, not always against the one the
+// walk started from, or the alias from a's perspective would be looked up
+// against b (and vice versa) and the cycle would go undetected.
+func TestHasCyclicFieldReferenceDetectsCycleWithPerSBRAliases(t *testing.T) {
+	a := types.NamespacedName{Namespace: "ns", Name: "a"}
+	b := types.NamespacedName{Namespace: "ns", Name: "b"}
+
+	// Both a and b happen to alias their peer as "peer", but which
+	// ServiceBinding that alias resolves to depends on who declared it.
+	byName := map[types.NamespacedName][]fieldReference{
+		a: {{Kind: fieldReferenceBindSecret, ServiceRef: "peer", Path: "password"}},
+		b: {{Kind: fieldReferenceBindSecret, ServiceRef: "peer", Path: "password"}},
+	}
+	resolveSecretOwner := func(visiting types.NamespacedName, serviceRef string) (types.NamespacedName, bool) {
+		if serviceRef != "peer" {
+			return types.NamespacedName{}, false
+		}
+		switch visiting {
+		case a:
+			return b, true
+		case b:
+			return a, true
+		default:
+			return types.NamespacedName{}, false
+		}
+	}
+
+	if !hasCyclicFieldReference(a, byName, resolveSecretOwner) {
+		t.Fatal("expected a cycle to be detected when each ServiceBinding uses its own alias for the other")
+	}
+}
+
+func TestHasCyclicFieldReferenceNoCycle(t *testing.T) {
+	a := types.NamespacedName{Namespace: "ns", Name: "a"}
+	b := types.NamespacedName{Namespace: "ns", Name: "b"}
+
+	// a references b's owned secret, b references nothing back.
+	byName := map[types.NamespacedName][]fieldReference{
+		a: {{Kind: fieldReferenceBindSecret, ServiceRef: "b-service", Path: "password"}},
+		b: nil,
+	}
+	resolveSecretOwner := func(visiting types.NamespacedName, serviceRef string) (types.NamespacedName, bool) {
+		if serviceRef == "b-service" {
+			return b, true
+		}
+		return types.NamespacedName{}, false
+	}
+
+	if hasCyclicFieldReference(a, byName, resolveSecretOwner) {
+		t.Fatal("expected no cycle to be detected")
+	}
+}
+
+func newTestReconciler(initObjs ...runtime.Object) *ServiceBindingReconciler {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	return &ServiceBindingReconciler{
+		Client:     fake.NewFakeClientWithScheme(scheme, initObjs...),
+		typeLookup: fakeTypeLookup{},
+		cache:      newSBRIndex(),
+	}
+}
+
+// TestResolveMappingsLiteralAndBindSecret covers a literal mapping value
+// passed through unchanged, and a bindsecret placeholder resolved by reading
+// a key straight out of another Secret's data.
+func TestResolveMappingsLiteralAndBindSecret(t *testing.T) {
+	otherSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "other-secret"},
+		Data:       map[string][]byte{"password": []byte("s3cr3t")},
+	}
+	r := newTestReconciler(otherSecret)
+
+	sbr := &v1alpha1.ServiceBinding{
+		Spec: v1alpha1.ServiceBindingSpec{
+			Services: []v1alpha1.Service{
+				{APIVersion: "v1", Kind: "Secret", Namespace: "ns1", Name: "other-secret"},
+			},
+			Mappings: []v1alpha1.Mapping{
+				{Name: "literal", Value: "not-a-reference"},
+				{Name: "password", Value: "{{ bindsecret:other-secret#password }}"},
+			},
+		},
+	}
+	sbr.Namespace = "ns1"
+	sbr.Name = "sbr1"
+
+	data, err := r.resolveMappings(sbr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data["literal"]) != "not-a-reference" {
+		t.Fatalf("expected literal value to pass through unchanged, got %q", data["literal"])
+	}
+	if string(data["password"]) != "s3cr3t" {
+		t.Fatalf("expected password to resolve from the referenced Secret, got %q", data["password"])
+	}
+}
+
+// TestResolveMappingsMissingReferenceErrors verifies that a bindsecret
+// reference to a Secret that doesn't exist yet surfaces as an error, so
+// Reconcile requeues (with the controller's default backoff) instead of
+// writing a partial Secret.
+func TestResolveMappingsMissingReferenceErrors(t *testing.T) {
+	r := newTestReconciler()
+
+	sbr := &v1alpha1.ServiceBinding{
+		Spec: v1alpha1.ServiceBindingSpec{
+			Services: []v1alpha1.Service{
+				{APIVersion: "v1", Kind: "Secret", Namespace: "ns1", Name: "missing-secret"},
+			},
+			Mappings: []v1alpha1.Mapping{
+				{Name: "password", Value: "{{ bindsecret:missing-secret#password }}"},
+			},
+		},
+	}
+	sbr.Namespace = "ns1"
+	sbr.Name = "sbr1"
+
+	if _, err := r.resolveMappings(sbr); err == nil {
+		t.Fatal("expected an error for a reference to a Secret that does not exist yet")
+	}
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// sbrSelectorEntry is a pre-compiled spec.application.labelSelector or
+// spec.services[].labelSelector belonging to a single SBR, stored per-GVK so
+// Map only has to walk the SBRs that actually watch a given GVK instead of
+// every SBR in the cluster.
+type sbrSelectorEntry struct {
+	sbrName   types.NamespacedName
+	namespace string
+	selector  labels.Selector
+}
+
+// matches reports whether e's selector accepts an object in namespace with
+// the given labels. namespace is the namespace the entry was indexed under
+// (the referenced service's own namespace, or the SBR's namespace for an
+// application selector), not necessarily the declaring SBR's namespace.
+func (e sbrSelectorEntry) matches(namespace string, objLabels map[string]string) bool {
+	return e.selector != nil && e.namespace == namespace && e.selector.Matches(labels.Set(objLabels))
+}
+
+// compileLabelSelector turns an API *metav1.LabelSelector into a
+// labels.Selector. Callers only invoke it once they've already checked sel is
+// non-nil.
+func compileLabelSelector(sel *metav1.LabelSelector) (labels.Selector, error) {
+	return metav1.LabelSelectorAsSelector(sel)
+}
+
+// matchSelectors returns the SBRs among entries whose selector accepts an
+// object in namespace with objLabels.
+func matchSelectors(entries []sbrSelectorEntry, namespace string, objLabels map[string]string) namespacedNameSet {
+	matched := make(namespacedNameSet)
+	for _, entry := range entries {
+		if entry.matches(namespace, objLabels) {
+			matched.add(entry.sbrName)
+		}
+	}
+	return matched
+}
+
+// removeSelectorEntries returns entries with every sbrSelectorEntry for
+// sbrName removed, preserving order of the rest.
+func removeSelectorEntries(entries []sbrSelectorEntry, sbrName types.NamespacedName) []sbrSelectorEntry {
+	if len(entries) == 0 {
+		return entries
+	}
+	filtered := entries[:0]
+	for _, e := range entries {
+		if e.sbrName != sbrName {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/redhat-developer/service-binding-operator/api/v1alpha1"
+)
+
+// TestApplicationSelectorTransitionsWithLabelChanges covers a workload whose
+// labels change so that it first does not match an SBR's
+// spec.application.labelSelector, then matches it, then stops matching again.
+func TestApplicationSelectorTransitionsWithLabelChanges(t *testing.T) {
+	lookup := fakeTypeLookup{}
+
+	sbr := &v1alpha1.ServiceBinding{
+		Spec: v1alpha1.ServiceBindingSpec{
+			Application: &v1alpha1.Application{
+				APIVersion:    "apps/v1",
+				Kind:          "Deployment",
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			},
+		},
+	}
+	sbr.Namespace = "ns1"
+	sbr.Name = "sbr1"
+
+	index := newSBRIndex()
+	index.add(lookup, sbr)
+
+	gvk := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	sbrName := types.NamespacedName{Namespace: "ns1", Name: "sbr1"}
+
+	// Workload doesn't match yet: no "app" label.
+	if matched := index.applicationSelectorsMatching(gvk, "ns1", map[string]string{"other": "label"}); matched[sbrName] {
+		t.Fatal("expected no match before the workload gains the selected label")
+	}
+
+	// Workload gains the "app: db" label and starts matching.
+	if matched := index.applicationSelectorsMatching(gvk, "ns1", map[string]string{"app": "db"}); !matched[sbrName] {
+		t.Fatal("expected a match once the workload's labels satisfy the selector")
+	}
+
+	// Workload's label changes again and stops matching.
+	if matched := index.applicationSelectorsMatching(gvk, "ns1", map[string]string{"app": "cache"}); matched[sbrName] {
+		t.Fatal("expected no match after the workload's label changes away from the selector")
+	}
+}
+
+// TestServiceSelectorRemovedOnSBRDelete verifies that removing a SBR from the
+// index (as happens on OnDelete, or before re-adding on OnUpdate) drops its
+// service selector entries so it stops matching.
+func TestServiceSelectorRemovedOnSBRDelete(t *testing.T) {
+	lookup := fakeTypeLookup{}
+
+	sbr := &v1alpha1.ServiceBinding{
+		Spec: v1alpha1.ServiceBindingSpec{
+			Services: []v1alpha1.Service{{
+				APIVersion:    "v1",
+				Kind:          "Secret",
+				Namespace:     "ns1",
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "db-secret"}},
+			}},
+		},
+	}
+	sbr.Namespace = "ns1"
+	sbr.Name = "sbr1"
+
+	index := newSBRIndex()
+	index.add(lookup, sbr)
+
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+	sbrName := types.NamespacedName{Namespace: "ns1", Name: "sbr1"}
+
+	if matched := index.serviceSelectorsMatching(gvk, "ns1", map[string]string{"role": "db-secret"}); !matched[sbrName] {
+		t.Fatal("expected a match while the SBR is indexed")
+	}
+
+	index.remove(sbrName)
+
+	if matched := index.serviceSelectorsMatching(gvk, "ns1", map[string]string{"role": "db-secret"}); matched[sbrName] {
+		t.Fatal("expected no match after the SBR is removed from the index")
+	}
+}
+
+// TestServiceSelectorDefaultsNamespace verifies that a label-selector Service
+// with no Namespace set is indexed under the SBR's own namespace, per
+// Service.Namespace's documented default, rather than under the empty
+// string (which resolveLabelSelected would otherwise pass to
+// client.InNamespace as "all namespaces").
+func TestServiceSelectorDefaultsNamespace(t *testing.T) {
+	lookup := fakeTypeLookup{}
+
+	sbr := &v1alpha1.ServiceBinding{
+		Spec: v1alpha1.ServiceBindingSpec{
+			Services: []v1alpha1.Service{{
+				APIVersion:    "v1",
+				Kind:          "Secret",
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "db-secret"}},
+			}},
+		},
+	}
+	sbr.Namespace = "ns1"
+	sbr.Name = "sbr1"
+
+	index := newSBRIndex()
+	index.add(lookup, sbr)
+
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+	sbrName := types.NamespacedName{Namespace: "ns1", Name: "sbr1"}
+
+	if matched := index.serviceSelectorsMatching(gvk, "ns1", map[string]string{"role": "db-secret"}); !matched[sbrName] {
+		t.Fatal("expected a match in the SBR's own namespace")
+	}
+	if matched := index.serviceSelectorsMatching(gvk, "ns2", map[string]string{"role": "db-secret"}); matched[sbrName] {
+		t.Fatal("expected no match in an unrelated namespace")
+	}
+}
+
+// TestSelectorIndexingRegistersWatch verifies that indexing a label-selector
+// based Application or Service also registers a watch for its GVK through
+// the cache's watcher callback. Without this, applicationSelectorsMatching
+// and serviceSelectorsMatching would never receive the events they need to
+// fire, since nothing would be watching the selected GVK.
+func TestSelectorIndexingRegistersWatch(t *testing.T) {
+	lookup := fakeTypeLookup{}
+
+	sbr := &v1alpha1.ServiceBinding{
+		Spec: v1alpha1.ServiceBindingSpec{
+			Application: &v1alpha1.Application{
+				APIVersion:    "apps/v1",
+				Kind:          "Deployment",
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			},
+			Services: []v1alpha1.Service{{
+				APIVersion:    "v1",
+				Kind:          "Secret",
+				Namespace:     "ns1",
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "db-secret"}},
+			}},
+		},
+	}
+	sbr.Namespace = "ns1"
+	sbr.Name = "sbr1"
+
+	watched := make(map[schema.GroupVersionKind]bool)
+	index := newSBRIndex()
+	index.watcher = func(gvk schema.GroupVersionKind) error {
+		watched[gvk] = true
+		return nil
+	}
+	index.add(lookup, sbr)
+
+	appGVK := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	svcGVK := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+
+	if !watched[appGVK] {
+		t.Fatal("expected a watch to be registered for the label-selected Application's GVK")
+	}
+	if !watched[svcGVK] {
+		t.Fatal("expected a watch to be registered for the label-selected Service's GVK")
+	}
+}
@@ -1,13 +1,12 @@
 package controllers
 
 import (
-	"context"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/dynamic"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -21,8 +20,11 @@ var (
 
 // sbrRequestMapper is the handler.Mapper interface implementation. It should influence the
 // enqueue process considering the resources informed.
+//
+// Instead of listing every ServiceBinding on each call, Map consults cache, an indexed view of
+// the ServiceBinding informer kept up to date by sbrIndexEventHandler.
 type sbrRequestMapper struct {
-	client     dynamic.Interface
+	cache      *sbrIndex
 	typeLookup K8STypeLookup
 }
 
@@ -39,50 +41,6 @@ func isSecret(obj runtime.Object) bool {
 	return obj.GetObjectKind().GroupVersionKind() == secretGVK
 }
 
-// isSBRService checks whether the given obj is a service in given sbr.
-func isSBRService(typeLookup K8STypeLookup, sbr *v1alpha1.ServiceBinding, obj runtime.Object) bool {
-	for _, svc := range sbr.Spec.Services {
-		gvk, err := typeLookup.KindForReferable(&svc)
-		if err != nil {
-			return false
-		}
-		if obj.GetObjectKind().GroupVersionKind() == *gvk {
-			return true
-		}
-	}
-	return false
-}
-
-// isSBRApplication checks whether the given obj is an application in given sbr.
-func isSBRApplication(
-	typeLookup K8STypeLookup,
-	app *v1alpha1.Application,
-	gvk schema.GroupVersionKind,
-	name string,
-) (bool, error) {
-	if app == nil {
-		return false, nil
-	}
-	appGVK, err := typeLookup.KindForReferable(app)
-
-	if err != nil {
-		return false, err
-	}
-
-	isEqual := gvk == *appGVK
-
-	if len(app.Name) > 0 {
-		isEqual = app.Name == name
-	}
-
-	return isEqual, nil
-}
-
-// isSecretOwnedBySBR checks whether the given obj is a secret owned by the given sbr.
-func isSecretOwnedBySBR(obj metav1.Object, sbr *v1alpha1.ServiceBinding) bool {
-	return sbr.GetNamespace() == obj.GetNamespace() && sbr.Status.Secret == obj.GetName()
-}
-
 // convertToSBR attempts to convert the given obj into a Service Binding.
 func convertToSBR(obj map[string]interface{}) (*v1alpha1.ServiceBinding, error) {
 	sbr := &v1alpha1.ServiceBinding{}
@@ -129,8 +87,6 @@ func (m *sbrRequestMapper) Map(obj handler.MapObject) []reconcile.Request {
 		"Object.Name", obj.Meta.GetName(),
 	)
 
-	namespacedNamesToReconcile := make(namespacedNameSet)
-
 	if isServiceBinding(obj.Object) {
 		requests := []reconcile.Request{
 			{NamespacedName: convertToNamespacedName(obj.Meta)},
@@ -139,57 +95,62 @@ func (m *sbrRequestMapper) Map(obj handler.MapObject) []reconcile.Request {
 		return requests
 	}
 
-	// note(isutton): The client handles retries on the operator behalf, so only unrecoverable errors
-	// are left.
-	//
-	// please see https://github.com/isutton/service-binding-operator/blob/e17445570bd3889bcf7499142350a3b81463c6be/vendor/k8s.io/client-go/rest/request.go#L723-L812
-	sbrList, err := m.client.Resource(v1alpha1.GroupVersionResource).List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Error(err, "listing SBRs")
-		return []reconcile.Request{}
-	}
+	gvk := obj.Object.GetObjectKind().GroupVersionKind()
+	name := convertToNamespacedName(obj.Meta)
 
-ITEMS:
-	for _, item := range sbrList.Items {
-		namespacedName := convertToNamespacedName(&item)
+	namespacedNamesToReconcile := make(namespacedNameSet)
 
-		sbr, err := convertToSBR(item.Object)
-		if err != nil {
-			log.Error(err, "converting unstructured to SBR")
-			continue ITEMS
+	if isSecret(obj.Object) {
+		for sbrName := range m.cache.ownedSecretsMatching(name) {
+			log.Debug("resource identified as a secret owned by the SBR", "NamespacedName", sbrName)
+			namespacedNamesToReconcile.add(sbrName)
 		}
-
-		if isSecret(obj.Object) && isSecretOwnedBySBR(obj.Meta, sbr) {
-			log.Debug("resource identified as a secret owned by the SBR")
-			namespacedNamesToReconcile.add(namespacedName)
-		} else {
-			log.Trace("resource is not a secret owned by the SBR")
+		for sbrName := range m.cache.serviceCatalogSecretsMatching(name) {
+			log.Debug("resource identified as a Service Catalog binding secret", "NamespacedName", sbrName)
+			namespacedNamesToReconcile.add(sbrName)
 		}
+	}
 
-		if isSBRService(m.typeLookup, sbr, obj.Object) {
-			log.Debug("resource identified as service in SBR", "NamespacedName", namespacedName)
-			namespacedNamesToReconcile.add(namespacedName)
-		} else {
-			log.Trace("resource is not a service declared by the SBR")
+	if isServiceCatalogServiceInstance(obj.Object) {
+		for sbrName := range m.cache.serviceCatalogInstancesMatching(name) {
+			log.Debug("resource identified as a Service Catalog ServiceInstance", "NamespacedName", sbrName)
+			namespacedNamesToReconcile.add(sbrName)
 		}
+	}
 
-		if ok, err := isSBRApplication(
-			m.typeLookup,
-			sbr.Spec.Application,
-			obj.Object.GetObjectKind().GroupVersionKind(),
-			obj.Meta.GetName(),
-		); err != nil {
-			log.Error(err, "identifying resource as SBR application")
-			continue ITEMS
-		} else if !ok {
-			log.Trace("resource is not an application declared by the SBR")
-			continue ITEMS
-		} else {
-			log.Debug("resource identified as an application in SBR", "NamespacedName", namespacedName)
-			namespacedNamesToReconcile.add(namespacedName)
+	if isServiceCatalogServiceBinding(obj.Object) {
+		if unstructuredObj, ok := obj.Object.(*unstructured.Unstructured); ok {
+			if ref, ok := readServiceCatalogBindingRef(obj.Meta.GetNamespace(), unstructuredObj.UnstructuredContent()); ok {
+				m.cache.observeServiceCatalogBinding(name, ref)
+			}
 		}
 	}
 
+	for sbrName := range m.cache.servicesMatching(gvk, name) {
+		log.Debug("resource identified as service in SBR", "NamespacedName", sbrName)
+		namespacedNamesToReconcile.add(sbrName)
+	}
+
+	for sbrName := range m.cache.applicationsMatching(gvk, name) {
+		log.Debug("resource identified as an application in SBR", "NamespacedName", sbrName)
+		namespacedNamesToReconcile.add(sbrName)
+	}
+
+	objLabels := obj.Meta.GetLabels()
+	for sbrName := range m.cache.applicationSelectorsMatching(gvk, name.Namespace, objLabels) {
+		log.Debug("resource labels match an SBR application selector", "NamespacedName", sbrName)
+		namespacedNamesToReconcile.add(sbrName)
+	}
+	for sbrName := range m.cache.serviceSelectorsMatching(gvk, name.Namespace, objLabels) {
+		log.Debug("resource labels match an SBR service selector", "NamespacedName", sbrName)
+		namespacedNamesToReconcile.add(sbrName)
+	}
+
+	for sbrName := range m.cache.fieldReferencesMatching(gvk, name) {
+		log.Debug("resource identified as a field reference in SBR", "NamespacedName", sbrName)
+		namespacedNamesToReconcile.add(sbrName)
+	}
+
 	requests := convertToRequests(namespacedNamesToReconcile)
 	if count := len(requests); count > 0 {
 		log.Debug("found SBRs for resource", "Count", count, "Requests", requests)
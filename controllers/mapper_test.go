@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/redhat-developer/service-binding-operator/api/v1alpha1"
+)
+
+// fakeTypeLookup resolves GVK/GVR straight off the Referable's own
+// APIVersion/Kind, which is all the SBRs built in these tests ever set.
+type fakeTypeLookup struct{}
+
+func (fakeTypeLookup) ResourceForReferable(obj Referable) (*schema.GroupVersionResource, error) {
+	return obj.GroupVersionResource()
+}
+
+func (fakeTypeLookup) KindForReferable(obj Referable) (*schema.GroupVersionKind, error) {
+	return obj.GroupVersionKind()
+}
+
+func (fakeTypeLookup) ResourceForKind(gvk schema.GroupVersionKind) (*schema.GroupVersionResource, error) {
+	return nil, errors.New("not supported in test")
+}
+
+func (fakeTypeLookup) KindForResource(gvr schema.GroupVersionResource) (*schema.GroupVersionKind, error) {
+	return nil, errors.New("not supported in test")
+}
+
+// newUnstructured builds an object usable as both handler.MapObject.Meta and
+// .Object: unstructured.Unstructured satisfies both metav1.Object and
+// runtime.Object.
+func newUnstructured(apiVersion, kind, namespace, name string, labels map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	u.SetLabels(labels)
+	return u
+}
+
+func requestNames(requests []reconcile.Request) map[types.NamespacedName]bool {
+	names := make(map[types.NamespacedName]bool, len(requests))
+	for _, r := range requests {
+		names[r.NamespacedName] = true
+	}
+	return names
+}
+
+// TestMapperIndexedLookupByExactReference builds a mapper backed by the
+// indexed sbrIndex cache and asserts it produces reconcile requests for a
+// declared Service event, an Application event matched by GVK only
+// (mirroring isSBRApplication's no-Name fallback, which the index keeps
+// verbatim), and a Secret owned by the SBR.
+//
+// It intentionally does NOT assert parity with the previous List()-based
+// isSBRService/isSBRApplication for a named reference: isSBRService matched
+// by GVK alone, ignoring namespace and name entirely, so e.g. any Secret event
+// anywhere would have reconciled every SBR with a Secret-kind service, and
+// isSBRApplication matched a named Application by name alone. The
+// index matches named Services/Applications by exact namespace+name, which
+// is a deliberate precision fix; see "unrelated object" below.
+func TestMapperIndexedLookupByExactReference(t *testing.T) {
+	lookup := fakeTypeLookup{}
+
+	sbr := &v1alpha1.ServiceBinding{
+		Spec: v1alpha1.ServiceBindingSpec{
+			Application: &v1alpha1.Application{APIVersion: "apps/v1", Kind: "Deployment"},
+			Services: []v1alpha1.Service{
+				{APIVersion: "v1", Kind: "Secret", Namespace: "ns1", Name: "db-service"},
+			},
+		},
+		Status: v1alpha1.ServiceBindingStatus{Secret: "owned-secret"},
+	}
+	sbr.Namespace = "ns1"
+	sbr.Name = "sbr1"
+
+	index := newSBRIndex()
+	index.add(lookup, sbr)
+
+	mapper := &sbrRequestMapper{cache: index, typeLookup: lookup}
+	expected := types.NamespacedName{Namespace: "ns1", Name: "sbr1"}
+
+	t.Run("declared service", func(t *testing.T) {
+		obj := newUnstructured("v1", "Secret", "ns1", "db-service", nil)
+		requests := mapper.Map(handler.MapObject{Meta: obj, Object: obj})
+		if !requestNames(requests)[expected] {
+			t.Fatalf("expected %v to be reconciled, got %v", expected, requests)
+		}
+	})
+
+	t.Run("application matched by GVK only", func(t *testing.T) {
+		obj := newUnstructured("apps/v1", "Deployment", "ns1", "any-deployment", nil)
+		requests := mapper.Map(handler.MapObject{Meta: obj, Object: obj})
+		if !requestNames(requests)[expected] {
+			t.Fatalf("expected %v to be reconciled, got %v", expected, requests)
+		}
+	})
+
+	t.Run("owned secret", func(t *testing.T) {
+		obj := newUnstructured("v1", "Secret", "ns1", "owned-secret", nil)
+		requests := mapper.Map(handler.MapObject{Meta: obj, Object: obj})
+		if !requestNames(requests)[expected] {
+			t.Fatalf("expected %v to be reconciled, got %v", expected, requests)
+		}
+	})
+
+	t.Run("unrelated object produces no requests", func(t *testing.T) {
+		// Same GVK and namespace as the declared Service, different name: the
+		// old GVK-only isSBRService would have matched this and reconciled
+		// sbr1 anyway. The exact-match index must not.
+		obj := newUnstructured("v1", "Secret", "ns1", "unrelated-secret", nil)
+		requests := mapper.Map(handler.MapObject{Meta: obj, Object: obj})
+		if len(requests) != 0 {
+			t.Fatalf("expected no requests, got %v", requests)
+		}
+	})
+
+	t.Run("service binding itself is always reconciled", func(t *testing.T) {
+		obj := newUnstructured("apps.openshift.io/v1alpha1", "ServiceBinding", "ns1", "sbr1", nil)
+		requests := mapper.Map(handler.MapObject{Meta: obj, Object: obj})
+		if !requestNames(requests)[expected] {
+			t.Fatalf("expected %v to be reconciled, got %v", expected, requests)
+		}
+	})
+}
+
+// TestMapperIndexUpdateDropsStaleEntries verifies that updating a SBR's
+// indexed entries (via sbrIndex.update, as the informer event handler does on
+// an OnUpdate) stops matching objects the SBR no longer references.
+func TestMapperIndexUpdateDropsStaleEntries(t *testing.T) {
+	lookup := fakeTypeLookup{}
+
+	sbr := &v1alpha1.ServiceBinding{
+		Spec: v1alpha1.ServiceBindingSpec{
+			Services: []v1alpha1.Service{{APIVersion: "v1", Kind: "Secret", Namespace: "ns1", Name: "old-service"}},
+		},
+	}
+	sbr.Namespace = "ns1"
+	sbr.Name = "sbr1"
+
+	index := newSBRIndex()
+	index.add(lookup, sbr)
+
+	sbr.Spec.Services = []v1alpha1.Service{{APIVersion: "v1", Kind: "Secret", Namespace: "ns1", Name: "new-service"}}
+	index.update(lookup, sbr)
+
+	mapper := &sbrRequestMapper{cache: index, typeLookup: lookup}
+
+	oldObj := newUnstructured("v1", "Secret", "ns1", "old-service", nil)
+	if requests := mapper.Map(handler.MapObject{Meta: oldObj, Object: oldObj}); len(requests) != 0 {
+		t.Fatalf("expected stale service reference to be dropped, got %v", requests)
+	}
+
+	newObj := newUnstructured("v1", "Secret", "ns1", "new-service", nil)
+	requests := mapper.Map(handler.MapObject{Meta: newObj, Object: newObj})
+	expected := types.NamespacedName{Namespace: "ns1", Name: "sbr1"}
+	if !requestNames(requests)[expected] {
+		t.Fatalf("expected %v to be reconciled for new service, got %v", expected, requests)
+	}
+}
+
+// TestMapperIndexDefaultsServiceNamespace verifies that a Service with no
+// Namespace set is indexed under the SBR's own namespace, per
+// Service.Namespace's documented default, rather than under the empty
+// string where it would never match the real object's events.
+func TestMapperIndexDefaultsServiceNamespace(t *testing.T) {
+	lookup := fakeTypeLookup{}
+
+	sbr := &v1alpha1.ServiceBinding{
+		Spec: v1alpha1.ServiceBindingSpec{
+			Services: []v1alpha1.Service{{APIVersion: "v1", Kind: "Secret", Name: "db-service"}},
+		},
+	}
+	sbr.Namespace = "ns1"
+	sbr.Name = "sbr1"
+
+	index := newSBRIndex()
+	index.add(lookup, sbr)
+
+	mapper := &sbrRequestMapper{cache: index, typeLookup: lookup}
+	expected := types.NamespacedName{Namespace: "ns1", Name: "sbr1"}
+
+	obj := newUnstructured("v1", "Secret", "ns1", "db-service", nil)
+	requests := mapper.Map(handler.MapObject{Meta: obj, Object: obj})
+	if !requestNames(requests)[expected] {
+		t.Fatalf("expected %v to be reconciled for namespace-less service, got %v", expected, requests)
+	}
+}
+
+// TestIndexAddSurfacesUnresolvedReference verifies that add returns an error
+// summarizing a Service whose GVK couldn't be resolved, rather than only
+// logging it and silently dropping it from the index forever.
+func TestIndexAddSurfacesUnresolvedReference(t *testing.T) {
+	lookup := fakeTypeLookup{}
+
+	sbr := &v1alpha1.ServiceBinding{
+		Spec: v1alpha1.ServiceBindingSpec{
+			// Malformed APIVersion: schema.ParseGroupVersion rejects more than
+			// one "/", so Service.GroupVersionKind fails.
+			Services: []v1alpha1.Service{{APIVersion: "a/b/c", Kind: "Secret", Name: "unresolvable"}},
+		},
+	}
+	sbr.Namespace = "ns1"
+	sbr.Name = "sbr1"
+
+	index := newSBRIndex()
+	if err := index.add(lookup, sbr); err == nil {
+		t.Fatal("expected add to return an error for an unresolvable service reference")
+	}
+}
@@ -0,0 +1,485 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/redhat-developer/service-binding-operator/api/v1alpha1"
+)
+
+var errNotAServiceBinding = errors.New("object is not a ServiceBinding")
+
+// sbrIndexKey identifies a bucket in sbrIndex by the GVK of a referenced
+// object together with its namespaced name.
+type sbrIndexKey struct {
+	gvk            schema.GroupVersionKind
+	namespacedName types.NamespacedName
+}
+
+// gvkWatcher registers a watch for gvk with the controller that owns this
+// index, so that resources of a GVK an SBR references actually produce events
+// for sbrRequestMapper.Map instead of only ever being looked up in the index
+// in vain. SetupWithManager supplies the real implementation; it is nil in
+// tests that drive the index directly, which is fine since ensureWatch is a
+// no-op without one.
+type gvkWatcher func(gvk schema.GroupVersionKind) error
+
+// sbrIndex keeps, for every ServiceBinding known to the informer cache,
+// reverse indexes from the objects it references to the SBRs that reference
+// them. sbrRequestMapper.Map consults these indexes instead of listing every
+// ServiceBinding on each event.
+//
+// byApplicationGVK holds SBRs whose Spec.Application has no Name set, and is
+// therefore matched against every instance of the application's GVK,
+// mirroring the fallback behaviour isSBRApplication used to implement.
+//
+// Unlike the List()-based isSBRService/isSBRApplication they replace,
+// byServiceGVKNamespaceName and byApplicationGVKNamespaceName key on the
+// referenced object's GVK, namespace and name: the old code matched a named
+// Service by GVK alone, so e.g. any Secret event anywhere would reconcile
+// every SBR declaring a Secret-kind service, and a named Application by name
+// alone, so it could match an object of the same name in a different
+// namespace or of a different kind. Exact-match indexing is an intentional precision
+// fix, not a faithful port of that looseness.
+type sbrIndex struct {
+	mu sync.RWMutex
+
+	byServiceGVKNamespaceName        map[sbrIndexKey]namespacedNameSet
+	byApplicationGVKNamespaceName    map[sbrIndexKey]namespacedNameSet
+	byApplicationGVK                 map[schema.GroupVersionKind]namespacedNameSet
+	byOwnedSecretNamespaceName       map[types.NamespacedName]namespacedNameSet
+	byFieldReferenceGVKNamespaceName map[sbrIndexKey]namespacedNameSet
+
+	// byServiceCatalogInstanceNamespaceName and byServiceCatalogSecretNamespaceName
+	// are learned from Service Catalog ServiceBinding objects as they're observed
+	// (see observeServiceCatalogBinding), rather than from a SBR's own Spec, since
+	// the ServiceInstance and output Secret a ServiceBinding references aren't known
+	// until the ServiceBinding itself has been seen.
+	byServiceCatalogInstanceNamespaceName map[types.NamespacedName]namespacedNameSet
+	byServiceCatalogSecretNamespaceName   map[types.NamespacedName]namespacedNameSet
+	serviceCatalogBindingState            map[types.NamespacedName]serviceCatalogBindingRef
+
+	// byApplicationSelectorGVK and byServiceSelectorGVK hold the pre-compiled
+	// spec.application.labelSelector / spec.services[].labelSelector for every SBR
+	// that uses one, keyed by the selected object's GVK so a lookup only walks the
+	// SBRs watching that GVK (O(#SBRs watching the GVK)) rather than every SBR.
+	byApplicationSelectorGVK map[schema.GroupVersionKind][]sbrSelectorEntry
+	byServiceSelectorGVK     map[schema.GroupVersionKind][]sbrSelectorEntry
+
+	watcher     gvkWatcher
+	watchedGVKs map[schema.GroupVersionKind]bool
+}
+
+func newSBRIndex() *sbrIndex {
+	return &sbrIndex{
+		byServiceGVKNamespaceName:        make(map[sbrIndexKey]namespacedNameSet),
+		byApplicationGVKNamespaceName:    make(map[sbrIndexKey]namespacedNameSet),
+		byApplicationGVK:                 make(map[schema.GroupVersionKind]namespacedNameSet),
+		byOwnedSecretNamespaceName:       make(map[types.NamespacedName]namespacedNameSet),
+		byFieldReferenceGVKNamespaceName: make(map[sbrIndexKey]namespacedNameSet),
+
+		byServiceCatalogInstanceNamespaceName: make(map[types.NamespacedName]namespacedNameSet),
+		byServiceCatalogSecretNamespaceName:   make(map[types.NamespacedName]namespacedNameSet),
+		serviceCatalogBindingState:            make(map[types.NamespacedName]serviceCatalogBindingRef),
+
+		byApplicationSelectorGVK: make(map[schema.GroupVersionKind][]sbrSelectorEntry),
+		byServiceSelectorGVK:     make(map[schema.GroupVersionKind][]sbrSelectorEntry),
+
+		watchedGVKs: make(map[schema.GroupVersionKind]bool),
+	}
+}
+
+// ensureWatch registers a watch for gvk the first time the index observes an
+// SBR referencing it. Call sites already hold idx.mu for writing.
+func (idx *sbrIndex) ensureWatch(gvk schema.GroupVersionKind) {
+	if idx.watcher == nil || idx.watchedGVKs[gvk] {
+		return
+	}
+	if err := idx.watcher(gvk); err != nil {
+		mapperLog.Error(err, "watching GVK referenced by a ServiceBinding", "GVK", gvk)
+		return
+	}
+	idx.watchedGVKs[gvk] = true
+}
+
+// addToSet records sbrName under key in set, creating set if necessary.
+func addToSet(m map[sbrIndexKey]namespacedNameSet, key sbrIndexKey, sbrName types.NamespacedName) {
+	set, ok := m[key]
+	if !ok {
+		set = make(namespacedNameSet)
+		m[key] = set
+	}
+	set.add(sbrName)
+}
+
+// remove deletes every entry for sbrName from the index. It is called before
+// re-adding a SBR on update, and on delete.
+func (idx *sbrIndex) remove(sbrName types.NamespacedName) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, set := range idx.byServiceGVKNamespaceName {
+		delete(set, sbrName)
+	}
+	for _, set := range idx.byApplicationGVKNamespaceName {
+		delete(set, sbrName)
+	}
+	for _, set := range idx.byApplicationGVK {
+		delete(set, sbrName)
+	}
+	for _, set := range idx.byOwnedSecretNamespaceName {
+		delete(set, sbrName)
+	}
+	for _, set := range idx.byFieldReferenceGVKNamespaceName {
+		delete(set, sbrName)
+	}
+	for _, set := range idx.byServiceCatalogInstanceNamespaceName {
+		delete(set, sbrName)
+	}
+	for _, set := range idx.byServiceCatalogSecretNamespaceName {
+		delete(set, sbrName)
+	}
+	for gvk, entries := range idx.byApplicationSelectorGVK {
+		idx.byApplicationSelectorGVK[gvk] = removeSelectorEntries(entries, sbrName)
+	}
+	for gvk, entries := range idx.byServiceSelectorGVK {
+		idx.byServiceSelectorGVK[gvk] = removeSelectorEntries(entries, sbrName)
+	}
+}
+
+// add registers sbr's current Services, Application and owned Secret in the
+// index, resolving GVKs through typeLookup. A reference that can't be
+// resolved yet (e.g. its CRD isn't registered with typeLookup at the moment
+// of this call) is logged and skipped rather than dropping the whole SBR, so
+// a single bad reference doesn't blind the mapper to the SBR's other
+// references; add instead returns an error summarizing every reference that
+// couldn't be resolved, so a caller with access to the reconcile queue (see
+// Reconcile, which calls update on every pass) can requeue the SBR and retry
+// once the missing type becomes available, rather than the reference being
+// dropped from the index forever.
+func (idx *sbrIndex) add(typeLookup K8STypeLookup, sbr *v1alpha1.ServiceBinding) error {
+	sbrName := convertToNamespacedName(sbr)
+	var unresolved []string
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for i := range sbr.Spec.Services {
+		svc := sbr.Spec.Services[i]
+		svcNamespace := svc.Namespace
+		if svcNamespace == "" {
+			svcNamespace = sbr.Namespace
+		}
+		gvk, err := typeLookup.KindForReferable(&svc)
+		if err != nil {
+			mapperLog.Error(err, "indexing SBR service", "ServiceBinding", sbrName, "Service", svc)
+			unresolved = append(unresolved, fmt.Sprintf("service %q: %v", svc.Name, err))
+			continue
+		}
+
+		if svc.LabelSelector != nil {
+			selector, err := compileLabelSelector(svc.LabelSelector)
+			if err != nil {
+				mapperLog.Error(err, "compiling SBR service label selector", "ServiceBinding", sbrName, "Service", svc)
+			} else {
+				idx.ensureWatch(*gvk)
+				idx.byServiceSelectorGVK[*gvk] = append(idx.byServiceSelectorGVK[*gvk], sbrSelectorEntry{
+					sbrName:   sbrName,
+					namespace: svcNamespace,
+					selector:  selector,
+				})
+			}
+			continue
+		}
+
+		idx.ensureWatch(*gvk)
+		addToSet(idx.byServiceGVKNamespaceName, sbrIndexKey{
+			gvk:            *gvk,
+			namespacedName: types.NamespacedName{Namespace: svcNamespace, Name: svc.Name},
+		}, sbrName)
+	}
+
+	if app := sbr.Spec.Application; app != nil {
+		gvk, err := typeLookup.KindForReferable(app)
+		if err != nil {
+			mapperLog.Error(err, "indexing SBR application", "ServiceBinding", sbrName, "Application", app)
+			unresolved = append(unresolved, fmt.Sprintf("application: %v", err))
+		} else if app.LabelSelector != nil {
+			selector, err := compileLabelSelector(app.LabelSelector)
+			if err != nil {
+				mapperLog.Error(err, "compiling SBR application label selector", "ServiceBinding", sbrName, "Application", app)
+			} else {
+				idx.ensureWatch(*gvk)
+				idx.byApplicationSelectorGVK[*gvk] = append(idx.byApplicationSelectorGVK[*gvk], sbrSelectorEntry{
+					sbrName:   sbrName,
+					namespace: sbr.Namespace,
+					selector:  selector,
+				})
+			}
+		} else if len(app.Name) > 0 {
+			idx.ensureWatch(*gvk)
+			addToSet(idx.byApplicationGVKNamespaceName, sbrIndexKey{
+				gvk:            *gvk,
+				namespacedName: types.NamespacedName{Namespace: sbr.Namespace, Name: app.Name},
+			}, sbrName)
+		} else {
+			idx.ensureWatch(*gvk)
+			set, ok := idx.byApplicationGVK[*gvk]
+			if !ok {
+				set = make(namespacedNameSet)
+				idx.byApplicationGVK[*gvk] = set
+			}
+			set.add(sbrName)
+		}
+	}
+
+	if sbr.Status.Secret != "" {
+		secretName := types.NamespacedName{Namespace: sbr.Namespace, Name: sbr.Status.Secret}
+		set, ok := idx.byOwnedSecretNamespaceName[secretName]
+		if !ok {
+			set = make(namespacedNameSet)
+			idx.byOwnedSecretNamespaceName[secretName] = set
+		}
+		set.add(sbrName)
+	}
+
+	// Field references in mappings/envVarMapping may target objects beyond the
+	// SBR's declared Services/Application, so they get their own index rather
+	// than reusing byServiceGVKNamespaceName.
+	for _, ref := range fieldReferencesInSBR(sbr) {
+		svc, err := resolveFieldReferenceService(sbr, ref)
+		if err != nil {
+			mapperLog.Error(err, "indexing SBR field reference", "ServiceBinding", sbrName, "FieldReference", ref)
+			continue
+		}
+		gvk, err := typeLookup.KindForReferable(svc)
+		if err != nil {
+			mapperLog.Error(err, "resolving GVK for SBR field reference", "ServiceBinding", sbrName, "FieldReference", ref)
+			unresolved = append(unresolved, fmt.Sprintf("field reference %q: %v", ref.ServiceRef, err))
+			continue
+		}
+		refNamespace := svc.Namespace
+		if refNamespace == "" {
+			refNamespace = sbr.Namespace
+		}
+		idx.ensureWatch(*gvk)
+		addToSet(idx.byFieldReferenceGVKNamespaceName, sbrIndexKey{
+			gvk:            *gvk,
+			namespacedName: types.NamespacedName{Namespace: refNamespace, Name: svc.Name},
+		}, sbrName)
+	}
+
+	if len(unresolved) > 0 {
+		return fmt.Errorf("ServiceBinding %s: %d reference(s) could not be resolved yet: %s",
+			sbrName, len(unresolved), strings.Join(unresolved, "; "))
+	}
+	return nil
+}
+
+// update re-indexes sbr, discarding whatever entries it held before so that
+// changes to Spec.Services, Spec.Application or Status.Secret are reflected.
+func (idx *sbrIndex) update(typeLookup K8STypeLookup, sbr *v1alpha1.ServiceBinding) error {
+	sbrName := convertToNamespacedName(sbr)
+	idx.remove(sbrName)
+	return idx.add(typeLookup, sbr)
+}
+
+// servicesMatching returns the SBRs that declare the object identified by gvk
+// and name as a service.
+func (idx *sbrIndex) servicesMatching(gvk schema.GroupVersionKind, name types.NamespacedName) namespacedNameSet {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.byServiceGVKNamespaceName[sbrIndexKey{gvk: gvk, namespacedName: name}]
+}
+
+// applicationsMatching returns the SBRs that declare the object identified by
+// gvk and name as their application, including SBRs that only constrain the
+// application by GVK.
+func (idx *sbrIndex) applicationsMatching(gvk schema.GroupVersionKind, name types.NamespacedName) namespacedNameSet {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	matched := make(namespacedNameSet)
+	for sbrName := range idx.byApplicationGVKNamespaceName[sbrIndexKey{gvk: gvk, namespacedName: name}] {
+		matched.add(sbrName)
+	}
+	for sbrName := range idx.byApplicationGVK[gvk] {
+		matched.add(sbrName)
+	}
+	return matched
+}
+
+// applicationSelectorsMatching returns the SBRs whose spec.application.labelSelector
+// matches an object of the given GVK, namespace and labels.
+func (idx *sbrIndex) applicationSelectorsMatching(gvk schema.GroupVersionKind, namespace string, objLabels map[string]string) namespacedNameSet {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return matchSelectors(idx.byApplicationSelectorGVK[gvk], namespace, objLabels)
+}
+
+// serviceSelectorsMatching returns the SBRs whose spec.services[].labelSelector
+// matches an object of the given GVK, namespace and labels.
+func (idx *sbrIndex) serviceSelectorsMatching(gvk schema.GroupVersionKind, namespace string, objLabels map[string]string) namespacedNameSet {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return matchSelectors(idx.byServiceSelectorGVK[gvk], namespace, objLabels)
+}
+
+// fieldReferencesMatching returns the SBRs whose mappings or envVarMapping
+// reference the object identified by gvk and name through a {{ service:... }}
+// or {{ bindsecret:... }} placeholder.
+func (idx *sbrIndex) fieldReferencesMatching(gvk schema.GroupVersionKind, name types.NamespacedName) namespacedNameSet {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.byFieldReferenceGVKNamespaceName[sbrIndexKey{gvk: gvk, namespacedName: name}]
+}
+
+// ownedSecretsMatching returns the SBRs whose Status.Secret is name.
+func (idx *sbrIndex) ownedSecretsMatching(name types.NamespacedName) namespacedNameSet {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.byOwnedSecretNamespaceName[name]
+}
+
+// observeServiceCatalogBinding records ref, the ServiceInstance and Secret a Service
+// Catalog ServiceBinding named bindingName currently points at, against every SBR
+// that declares that ServiceBinding as a service (found via servicesMatching). Stale
+// entries from a previous secretName/instanceRef are dropped, so a provisioning-time
+// secret rename is picked up on the next event for the ServiceBinding.
+//
+// Instance/Secret events are only fanned out to the owning SBRs once ref.ready is
+// true: until Service Catalog reports status.ready, the instanceRef/secretName it
+// carries may still be provisional, and projecting a not-yet-ready binding's Secret
+// would race with provisioning. The ServiceBinding event itself still reaches the
+// owning SBRs regardless of ready, via servicesMatching in Map, so a ready
+// transition is always observed.
+func (idx *sbrIndex) observeServiceCatalogBinding(bindingName types.NamespacedName, ref serviceCatalogBindingRef) {
+	sbrNames := idx.servicesMatching(serviceCatalogServiceBindingGVK, bindingName)
+	if len(sbrNames) == 0 {
+		return
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if prev, ok := idx.serviceCatalogBindingState[bindingName]; ok {
+		for sbrName := range sbrNames {
+			delete(idx.byServiceCatalogInstanceNamespaceName[prev.instance], sbrName)
+			delete(idx.byServiceCatalogSecretNamespaceName[prev.secret], sbrName)
+		}
+	}
+	idx.serviceCatalogBindingState[bindingName] = ref
+
+	if !ref.ready {
+		return
+	}
+
+	for sbrName := range sbrNames {
+		if ref.instance != (types.NamespacedName{}) {
+			set, ok := idx.byServiceCatalogInstanceNamespaceName[ref.instance]
+			if !ok {
+				set = make(namespacedNameSet)
+				idx.byServiceCatalogInstanceNamespaceName[ref.instance] = set
+			}
+			set.add(sbrName)
+		}
+		if ref.secret != (types.NamespacedName{}) {
+			set, ok := idx.byServiceCatalogSecretNamespaceName[ref.secret]
+			if !ok {
+				set = make(namespacedNameSet)
+				idx.byServiceCatalogSecretNamespaceName[ref.secret] = set
+			}
+			set.add(sbrName)
+		}
+	}
+}
+
+// serviceCatalogInstancesMatching returns the SBRs that, through a declared Service
+// Catalog ServiceBinding, are tied to the ServiceInstance identified by name.
+func (idx *sbrIndex) serviceCatalogInstancesMatching(name types.NamespacedName) namespacedNameSet {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.byServiceCatalogInstanceNamespaceName[name]
+}
+
+// serviceCatalogSecretsMatching returns the SBRs that, through a declared Service
+// Catalog ServiceBinding, are tied to the output Secret identified by name.
+func (idx *sbrIndex) serviceCatalogSecretsMatching(name types.NamespacedName) namespacedNameSet {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.byServiceCatalogSecretNamespaceName[name]
+}
+
+// sbrIndexEventHandler implements cache.ResourceEventHandler so sbrIndex can
+// be wired up directly as the ServiceBinding informer's event handler,
+// keeping the index in sync with Spec/Status changes as they happen.
+type sbrIndexEventHandler struct {
+	index      *sbrIndex
+	typeLookup K8STypeLookup
+}
+
+// newSBRIndexEventHandler returns a cache.ResourceEventHandler that keeps
+// index up to date with the ServiceBinding informer it is registered on.
+func newSBRIndexEventHandler(index *sbrIndex, typeLookup K8STypeLookup) cache.ResourceEventHandler {
+	return &sbrIndexEventHandler{index: index, typeLookup: typeLookup}
+}
+
+// OnAdd and OnUpdate index sbr on a best-effort basis: any reference that
+// fails to resolve here is logged and left out of the index by add/update,
+// but since this handler has no way to requeue the ServiceBinding, the
+// authoritative retry happens in Reconcile, which re-runs update on every
+// pass and requeues on error.
+func (h *sbrIndexEventHandler) OnAdd(obj interface{}) {
+	sbr, err := toServiceBinding(obj)
+	if err != nil {
+		mapperLog.Error(err, "indexing new SBR")
+		return
+	}
+	if err := h.index.add(h.typeLookup, sbr); err != nil {
+		mapperLog.Error(err, "indexing new SBR", "ServiceBinding", convertToNamespacedName(sbr))
+	}
+}
+
+func (h *sbrIndexEventHandler) OnUpdate(_, newObj interface{}) {
+	sbr, err := toServiceBinding(newObj)
+	if err != nil {
+		mapperLog.Error(err, "indexing updated SBR")
+		return
+	}
+	if err := h.index.update(h.typeLookup, sbr); err != nil {
+		mapperLog.Error(err, "indexing updated SBR", "ServiceBinding", convertToNamespacedName(sbr))
+	}
+}
+
+func (h *sbrIndexEventHandler) OnDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	sbr, err := toServiceBinding(obj)
+	if err != nil {
+		mapperLog.Error(err, "indexing deleted SBR")
+		return
+	}
+	h.index.remove(convertToNamespacedName(sbr))
+}
+
+// toServiceBinding converts an informer object, which may be an
+// *unstructured.Unstructured or an already typed *v1alpha1.ServiceBinding,
+// into a *v1alpha1.ServiceBinding.
+func toServiceBinding(obj interface{}) (*v1alpha1.ServiceBinding, error) {
+	switch v := obj.(type) {
+	case *v1alpha1.ServiceBinding:
+		return v, nil
+	case runtime.Unstructured:
+		return convertToSBR(v.UnstructuredContent())
+	default:
+		return nil, errNotAServiceBinding
+	}
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/redhat-developer/service-binding-operator/api/v1alpha1"
+)
+
+// serviceCatalogServiceBindingGVK and serviceCatalogServiceInstanceGVK identify the
+// Service Catalog resources sbrRequestMapper gives special treatment to: a SBR may
+// declare a servicecatalog.k8s.io/v1beta1 ServiceBinding as one of its Services, and
+// provisioning can later rename the ServiceInstance's output Secret or the binding
+// itself can flip Ready independently of the SBR.
+var (
+	serviceCatalogServiceBindingGVK  = schema.GroupVersionKind{Group: "servicecatalog.k8s.io", Version: "v1beta1", Kind: "ServiceBinding"}
+	serviceCatalogServiceInstanceGVK = schema.GroupVersionKind{Group: "servicecatalog.k8s.io", Version: "v1beta1", Kind: "ServiceInstance"}
+)
+
+// isServiceCatalogServiceBinding checks whether obj is a Service Catalog ServiceBinding.
+func isServiceCatalogServiceBinding(obj runtime.Object) bool {
+	return obj.GetObjectKind().GroupVersionKind() == serviceCatalogServiceBindingGVK
+}
+
+// isServiceCatalogServiceInstance checks whether obj is a Service Catalog ServiceInstance.
+func isServiceCatalogServiceInstance(obj runtime.Object) bool {
+	return obj.GetObjectKind().GroupVersionKind() == serviceCatalogServiceInstanceGVK
+}
+
+// serviceCatalogBindingRef holds the fields sbrIndex needs from a Service Catalog
+// ServiceBinding to fan events on its ServiceInstance and output Secret out to the
+// SBRs that reference it.
+type serviceCatalogBindingRef struct {
+	instance types.NamespacedName
+	secret   types.NamespacedName
+	ready    bool
+}
+
+// readServiceCatalogBindingRef extracts instanceRef.name, secretName and status.ready
+// from a Service Catalog ServiceBinding's unstructured content.
+func readServiceCatalogBindingRef(namespace string, content map[string]interface{}) (serviceCatalogBindingRef, bool) {
+	instanceName, _, _ := unstructured.NestedString(content, "spec", "instanceRef", "name")
+	secretName, _, _ := unstructured.NestedString(content, "spec", "secretName")
+	ready, _, _ := unstructured.NestedBool(content, "status", "ready")
+
+	if instanceName == "" && secretName == "" {
+		return serviceCatalogBindingRef{}, false
+	}
+
+	ref := serviceCatalogBindingRef{ready: ready}
+	if instanceName != "" {
+		ref.instance = types.NamespacedName{Namespace: namespace, Name: instanceName}
+	}
+	if secretName != "" {
+		ref.secret = types.NamespacedName{Namespace: namespace, Name: secretName}
+	}
+	return ref, true
+}
+
+// resolveServiceCatalogSecretData reads the output Secret of every declared
+// Service that is itself a ready Service Catalog ServiceBinding, and returns
+// its keys verbatim so sbr's target Secret carries the actual values Service
+// Catalog provisioned, rather than requiring one explicit bindsecret mapping
+// per key. A not-yet-ready binding contributes nothing yet; its keys appear
+// once status.ready flips true and the owning SBR is reconciled again (see
+// sbrIndex.observeServiceCatalogBinding).
+func (r *ServiceBindingReconciler) resolveServiceCatalogSecretData(sbr *v1alpha1.ServiceBinding) (map[string][]byte, error) {
+	data := make(map[string][]byte)
+
+	for i := range sbr.Spec.Services {
+		svc := sbr.Spec.Services[i]
+		gvk, err := r.typeLookup.KindForReferable(&svc)
+		if err != nil || *gvk != serviceCatalogServiceBindingGVK {
+			continue
+		}
+
+		namespace := svc.Namespace
+		if namespace == "" {
+			namespace = sbr.Namespace
+		}
+
+		binding := &unstructured.Unstructured{}
+		binding.SetGroupVersionKind(serviceCatalogServiceBindingGVK)
+		if err := r.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: svc.Name}, binding); err != nil {
+			return nil, err
+		}
+
+		ref, ok := readServiceCatalogBindingRef(namespace, binding.UnstructuredContent())
+		if !ok || !ref.ready {
+			continue
+		}
+
+		secret := &corev1.Secret{}
+		if err := r.Get(context.TODO(), ref.secret, secret); err != nil {
+			return nil, err
+		}
+		for key, value := range secret.Data {
+			data[key] = value
+		}
+	}
+
+	return data, nil
+}
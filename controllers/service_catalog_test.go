@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/redhat-developer/service-binding-operator/api/v1alpha1"
+)
+
+// fakeServiceCatalogBindingContent builds the unstructured content a
+// servicecatalog.k8s.io/v1beta1 ServiceBinding would carry, mimicking just
+// enough of its schema (spec.instanceRef.name, spec.secretName, status.ready)
+// for readServiceCatalogBindingRef.
+func fakeServiceCatalogBindingContent(instanceName, secretName string, ready bool) map[string]interface{} {
+	return map[string]interface{}{
+		"spec": map[string]interface{}{
+			"instanceRef": map[string]interface{}{
+				"name": instanceName,
+			},
+			"secretName": secretName,
+		},
+		"status": map[string]interface{}{
+			"ready": ready,
+		},
+	}
+}
+
+func TestReadServiceCatalogBindingRef(t *testing.T) {
+	content := fakeServiceCatalogBindingContent("my-instance", "my-secret", true)
+	ref, ok := readServiceCatalogBindingRef("ns1", content)
+	if !ok {
+		t.Fatal("expected ref to be parsed")
+	}
+	if ref.instance != (types.NamespacedName{Namespace: "ns1", Name: "my-instance"}) {
+		t.Fatalf("unexpected instance: %+v", ref.instance)
+	}
+	if ref.secret != (types.NamespacedName{Namespace: "ns1", Name: "my-secret"}) {
+		t.Fatalf("unexpected secret: %+v", ref.secret)
+	}
+	if !ref.ready {
+		t.Fatal("expected ready to be true")
+	}
+}
+
+func TestObserveServiceCatalogBindingNotYetReady(t *testing.T) {
+	lookup := fakeTypeLookup{}
+
+	sbr := &v1alpha1.ServiceBinding{
+		Spec: v1alpha1.ServiceBindingSpec{
+			Services: []v1alpha1.Service{
+				{APIVersion: "servicecatalog.k8s.io/v1beta1", Kind: "ServiceBinding", Namespace: "ns1", Name: "sc-binding"},
+			},
+		},
+	}
+	sbr.Namespace = "ns1"
+	sbr.Name = "sbr1"
+
+	index := newSBRIndex()
+	index.add(lookup, sbr)
+
+	bindingName := types.NamespacedName{Namespace: "ns1", Name: "sc-binding"}
+	notReady, ok := readServiceCatalogBindingRef("ns1", fakeServiceCatalogBindingContent("instance1", "secret1", false))
+	if !ok {
+		t.Fatal("expected ref to be parsed")
+	}
+	index.observeServiceCatalogBinding(bindingName, notReady)
+
+	if matched := index.serviceCatalogInstancesMatching(types.NamespacedName{Namespace: "ns1", Name: "instance1"}); len(matched) != 0 {
+		t.Fatalf("expected not-ready binding's instance not to be indexed, got %v", matched)
+	}
+	if matched := index.serviceCatalogSecretsMatching(types.NamespacedName{Namespace: "ns1", Name: "secret1"}); len(matched) != 0 {
+		t.Fatalf("expected not-ready binding's secret not to be indexed, got %v", matched)
+	}
+
+	ready, ok := readServiceCatalogBindingRef("ns1", fakeServiceCatalogBindingContent("instance1", "secret1", true))
+	if !ok {
+		t.Fatal("expected ref to be parsed")
+	}
+	index.observeServiceCatalogBinding(bindingName, ready)
+
+	if matched := index.serviceCatalogSecretsMatching(types.NamespacedName{Namespace: "ns1", Name: "secret1"}); !matched[types.NamespacedName{Namespace: "ns1", Name: "sbr1"}] {
+		t.Fatalf("expected ready binding's secret to be indexed, got %v", matched)
+	}
+}
+
+func TestObserveServiceCatalogBindingSecretRename(t *testing.T) {
+	lookup := fakeTypeLookup{}
+
+	sbr := &v1alpha1.ServiceBinding{
+		Spec: v1alpha1.ServiceBindingSpec{
+			Services: []v1alpha1.Service{
+				{APIVersion: "servicecatalog.k8s.io/v1beta1", Kind: "ServiceBinding", Namespace: "ns1", Name: "sc-binding"},
+			},
+		},
+	}
+	sbr.Namespace = "ns1"
+	sbr.Name = "sbr1"
+
+	index := newSBRIndex()
+	index.add(lookup, sbr)
+
+	bindingName := types.NamespacedName{Namespace: "ns1", Name: "sc-binding"}
+	sbrName := types.NamespacedName{Namespace: "ns1", Name: "sbr1"}
+
+	first, _ := readServiceCatalogBindingRef("ns1", fakeServiceCatalogBindingContent("instance1", "old-secret", true))
+	index.observeServiceCatalogBinding(bindingName, first)
+	if !index.serviceCatalogSecretsMatching(types.NamespacedName{Namespace: "ns1", Name: "old-secret"})[sbrName] {
+		t.Fatal("expected old secret to be indexed")
+	}
+
+	renamed, _ := readServiceCatalogBindingRef("ns1", fakeServiceCatalogBindingContent("instance1", "new-secret", true))
+	index.observeServiceCatalogBinding(bindingName, renamed)
+
+	if matched := index.serviceCatalogSecretsMatching(types.NamespacedName{Namespace: "ns1", Name: "old-secret"}); len(matched) != 0 {
+		t.Fatalf("expected old secret entry to be dropped after rename, got %v", matched)
+	}
+	if !index.serviceCatalogSecretsMatching(types.NamespacedName{Namespace: "ns1", Name: "new-secret"})[sbrName] {
+		t.Fatal("expected renamed secret to be indexed")
+	}
+}
+
+func newFakeServiceCatalogBinding(namespace, name, instanceName, secretName string, ready bool) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: fakeServiceCatalogBindingContent(instanceName, secretName, ready)}
+	u.SetGroupVersionKind(serviceCatalogServiceBindingGVK)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u
+}
+
+// TestResolveServiceCatalogSecretDataProjectsReadyBindingSecret verifies that
+// a declared Service Catalog ServiceBinding's output Secret keys are copied
+// verbatim once status.ready is true.
+func TestResolveServiceCatalogSecretDataProjectsReadyBindingSecret(t *testing.T) {
+	binding := newFakeServiceCatalogBinding("ns1", "sc-binding", "my-instance", "sc-secret", true)
+	outputSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "sc-secret"},
+		Data:       map[string][]byte{"host": []byte("db.internal"), "port": []byte("5432")},
+	}
+	r := newTestReconciler(binding, outputSecret)
+
+	sbr := &v1alpha1.ServiceBinding{
+		Spec: v1alpha1.ServiceBindingSpec{
+			Services: []v1alpha1.Service{
+				{APIVersion: "servicecatalog.k8s.io/v1beta1", Kind: "ServiceBinding", Namespace: "ns1", Name: "sc-binding"},
+			},
+		},
+	}
+	sbr.Namespace = "ns1"
+	sbr.Name = "sbr1"
+
+	data, err := r.resolveServiceCatalogSecretData(sbr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data["host"]) != "db.internal" || string(data["port"]) != "5432" {
+		t.Fatalf("expected ready binding's Secret keys to be projected verbatim, got %+v", data)
+	}
+}
+
+// TestResolveServiceCatalogSecretDataSkipsNotYetReady verifies that a
+// declared Service Catalog ServiceBinding not yet reporting status.ready
+// contributes no data, rather than projecting a possibly-provisional Secret.
+func TestResolveServiceCatalogSecretDataSkipsNotYetReady(t *testing.T) {
+	binding := newFakeServiceCatalogBinding("ns1", "sc-binding", "my-instance", "sc-secret", false)
+	outputSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "sc-secret"},
+		Data:       map[string][]byte{"host": []byte("db.internal")},
+	}
+	r := newTestReconciler(binding, outputSecret)
+
+	sbr := &v1alpha1.ServiceBinding{
+		Spec: v1alpha1.ServiceBindingSpec{
+			Services: []v1alpha1.Service{
+				{APIVersion: "servicecatalog.k8s.io/v1beta1", Kind: "ServiceBinding", Namespace: "ns1", Name: "sc-binding"},
+			},
+		},
+	}
+	sbr.Namespace = "ns1"
+	sbr.Name = "sbr1"
+
+	data, err := r.resolveServiceCatalogSecretData(sbr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(data) != 0 {
+		t.Fatalf("expected no data from a not-yet-ready binding, got %+v", data)
+	}
+}
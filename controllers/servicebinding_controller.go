@@ -0,0 +1,343 @@
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/redhat-developer/service-binding-operator/api/v1alpha1"
+	"github.com/redhat-developer/service-binding-operator/pkg/log"
+)
+
+var reconcilerLog = log.NewLog("controller_servicebinding")
+
+// ServiceBindingReconciler reconciles a ServiceBinding object.
+type ServiceBindingReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	restMapper meta.RESTMapper
+	typeLookup K8STypeLookup
+	cache      *sbrIndex
+}
+
+// Reconcile projects a ServiceBinding's declared Services, Application and custom
+// mappings into its target Secret.
+func (r *ServiceBindingReconciler) Reconcile(req reconcile.Request) (reconcile.Result, error) {
+	log := reconcilerLog.WithValues("ServiceBinding", req.NamespacedName)
+
+	sbr := &v1alpha1.ServiceBinding{}
+	if err := r.Get(context.TODO(), req.NamespacedName, sbr); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		log.Error(err, "getting ServiceBinding")
+		return reconcile.Result{}, err
+	}
+
+	// Re-run indexing here, not just from the informer event handler that
+	// normally keeps r.cache in sync: update is idempotent (it discards sbr's
+	// prior entries before re-adding), and unlike the event handler,
+	// Reconcile can requeue on error, so a reference that couldn't be
+	// resolved yet (e.g. its CRD hasn't registered) gets retried instead of
+	// being silently dropped from the index forever.
+	if err := r.cache.update(r.typeLookup, sbr); err != nil {
+		log.Debug("ServiceBinding reference did not resolve, will retry", "error", err.Error())
+		return reconcile.Result{}, err
+	}
+
+	if cyclic := r.detectCyclicFieldReference(sbr); cyclic {
+		log.Debug("cyclic field reference detected, marking Ready=False")
+		return reconcile.Result{}, r.setReadyCondition(sbr, corev1.ConditionFalse, "CyclicFieldReference",
+			"one or more field references form a cycle through another ServiceBinding's owned Secret")
+	}
+
+	mappingData, err := r.resolveMappings(sbr)
+	if err != nil {
+		log.Debug("field reference did not resolve, will retry", "error", err.Error())
+		return reconcile.Result{}, err
+	}
+
+	scData, err := r.resolveServiceCatalogSecretData(sbr)
+	if err != nil {
+		log.Debug("Service Catalog binding Secret did not resolve, will retry", "error", err.Error())
+		return reconcile.Result{}, err
+	}
+
+	// scData's raw, verbatim keys are the baseline; an explicit mapping or
+	// envVarMapping entry of the same name takes precedence over it.
+	data := make(map[string][]byte, len(scData)+len(mappingData))
+	for key, value := range scData {
+		data[key] = value
+	}
+	for key, value := range mappingData {
+		data[key] = value
+	}
+
+	if err := r.projectSelectedServices(sbr, data); err != nil {
+		log.Error(err, "projecting label-selected services")
+		return reconcile.Result{}, err
+	}
+
+	if err := r.setReadyCondition(sbr, corev1.ConditionTrue, "ProjectionSucceeded",
+		"the ServiceBinding's Services, Application and custom mappings were projected into its target Secret"); err != nil {
+		log.Error(err, "updating Ready condition")
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// projectSelectedServices resolves every currently-matching object for sbr's
+// label-selector-based Application and Services and writes their names into
+// sbr's target Secret alongside mappingData (the Service Catalog binding
+// Secret keys and the resolved sbr.Spec.Mappings and EnvVarMapping entries
+// that Reconcile merged). This only
+// surfaces which objects matched a selector at reconcile time, not any data
+// extracted from them: the module has no machinery to detect bindable data
+// on an arbitrary GVK (DetectBindingResources on the API type is likewise
+// unimplemented), so a label-selector match cannot yet contribute anything
+// beyond its own name. References addressed by Name rather than LabelSelector
+// are skipped here: what is projected from them is already in mappingData.
+func (r *ServiceBindingReconciler) projectSelectedServices(sbr *v1alpha1.ServiceBinding, mappingData map[string][]byte) error {
+	data := make(map[string][]byte, len(mappingData))
+	for key, value := range mappingData {
+		data[key] = value
+	}
+
+	if app := sbr.Spec.Application; app != nil && app.LabelSelector != nil {
+		names, err := r.resolveLabelSelected(app, app.LabelSelector, sbr.Namespace, "application")
+		if err != nil {
+			return err
+		}
+		for key, name := range names {
+			data[key] = []byte(name)
+		}
+	}
+
+	for i := range sbr.Spec.Services {
+		svc := sbr.Spec.Services[i]
+		if svc.LabelSelector == nil {
+			continue
+		}
+
+		svcNamespace := svc.Namespace
+		if svcNamespace == "" {
+			svcNamespace = sbr.Namespace
+		}
+		names, err := r.resolveLabelSelected(&svc, svc.LabelSelector, svcNamespace, svc.Name)
+		if err != nil {
+			return err
+		}
+		for key, name := range names {
+			data[key] = []byte(name)
+		}
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	secretName := sbr.Name
+	secret := &corev1.Secret{}
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: sbr.Namespace, Name: secretName}, secret)
+	if apierrors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: sbr.Namespace, Name: secretName},
+			Data:       data,
+		}
+		if err := ctrl.SetControllerReference(sbr, secret, r.Scheme); err != nil {
+			return err
+		}
+		if err := r.Create(context.TODO(), secret); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else {
+		secret.Data = data
+		if err := r.Update(context.TODO(), secret); err != nil {
+			return err
+		}
+	}
+
+	sbr.Status.Secret = secretName
+	return r.Status().Update(context.TODO(), sbr)
+}
+
+// resolveLabelSelected lists every object of ref's GVK in namespace matching
+// selector, returning a map from a Secret data key derived from each match to
+// its name, so the whole matching set is aggregated rather than only the
+// first match. keyPrefix disambiguates entries from different Services (or
+// the Application) in the aggregated Secret. The value is always the
+// matched object's own name: this is an inventory of what matched, not a
+// projection of any data read from the object.
+func (r *ServiceBindingReconciler) resolveLabelSelected(ref Referable, selector *metav1.LabelSelector, namespace, keyPrefix string) (map[string]string, error) {
+	gvk, err := r.typeLookup.KindForReferable(ref)
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := compileLabelSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(schema.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind + "List"})
+	if err := r.List(context.TODO(), list, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: compiled}); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(list.Items))
+	for i := range list.Items {
+		item := &list.Items[i]
+		names[keyPrefix+"_"+item.GetName()] = item.GetName()
+	}
+	return names, nil
+}
+
+// detectCyclicFieldReference checks whether resolving sbr's bindsecret field
+// references would eventually require resolving sbr itself again, by
+// following other ServiceBindings in the same namespace. It returns early,
+// without listing siblings, when sbr has no bindsecret reference at all: a
+// cycle can only start from one, and a namespace-wide List on every Reconcile
+// of every SBR (most of which don't use the feature) would not scale to a
+// cluster with many ServiceBindings.
+func (r *ServiceBindingReconciler) detectCyclicFieldReference(sbr *v1alpha1.ServiceBinding) bool {
+	hasBindSecretRef := false
+	for _, ref := range fieldReferencesInSBR(sbr) {
+		if ref.Kind == fieldReferenceBindSecret {
+			hasBindSecretRef = true
+			break
+		}
+	}
+	if !hasBindSecretRef {
+		return false
+	}
+
+	var siblings v1alpha1.ServiceBindingList
+	if err := r.List(context.TODO(), &siblings, client.InNamespace(sbr.Namespace)); err != nil {
+		reconcilerLog.Error(err, "listing ServiceBindings to check for cyclic field references", "ServiceBinding", sbr.Name)
+		return false
+	}
+
+	byName := make(map[types.NamespacedName][]fieldReference, len(siblings.Items))
+	byBinding := make(map[types.NamespacedName]*v1alpha1.ServiceBinding, len(siblings.Items))
+	byOwnedSecret := make(map[string]types.NamespacedName, len(siblings.Items))
+	for i := range siblings.Items {
+		s := &siblings.Items[i]
+		name := convertToNamespacedName(s)
+		byName[name] = fieldReferencesInSBR(s)
+		byBinding[name] = s
+		if s.Status.Secret != "" {
+			byOwnedSecret[s.Status.Secret] = name
+		}
+	}
+
+	return hasCyclicFieldReference(convertToNamespacedName(sbr), byName, func(visiting types.NamespacedName, serviceRef string) (types.NamespacedName, bool) {
+		visitingSBR, ok := byBinding[visiting]
+		if !ok {
+			return types.NamespacedName{}, false
+		}
+		svc, err := resolveFieldReferenceService(visitingSBR, fieldReference{ServiceRef: serviceRef})
+		if err != nil {
+			return types.NamespacedName{}, false
+		}
+		owner, ok := byOwnedSecret[svc.Name]
+		return owner, ok
+	})
+}
+
+// setReadyCondition updates sbr's Ready condition and persists its status.
+func (r *ServiceBindingReconciler) setReadyCondition(sbr *v1alpha1.ServiceBinding, status corev1.ConditionStatus, reason, message string) error {
+	condition := v1alpha1.Condition{
+		Type:    v1alpha1.BindingReady,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+
+	found := false
+	for i := range sbr.Status.Conditions {
+		if sbr.Status.Conditions[i].Type == v1alpha1.BindingReady {
+			sbr.Status.Conditions[i] = condition
+			found = true
+			break
+		}
+	}
+	if !found {
+		sbr.Status.Conditions = append(sbr.Status.Conditions, condition)
+	}
+
+	return r.Status().Update(context.TODO(), sbr)
+}
+
+// SetupWithManager wires the ServiceBinding informer's indexed cache and the
+// watches sbrRequestMapper relies on into mgr. Beyond the fixed set of GVKs
+// the mapper always cares about (Secret, the Service Catalog ServiceBinding
+// and ServiceInstance), it also hands the cache a watcher callback so that,
+// the first time an SBR is indexed against some other GVK as its Application
+// or a named Service, a watch for that GVK is registered too — without it,
+// the byServiceGVKNamespaceName/byApplicationGVKNamespaceName indexes would
+// never see an event to look anything up against.
+func (r *ServiceBindingReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.restMapper = mgr.GetRESTMapper()
+	r.typeLookup = r
+
+	r.cache = newSBRIndex()
+
+	ctl, err := ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.ServiceBinding{}).
+		Build(r)
+	if err != nil {
+		return err
+	}
+
+	mapper := &sbrRequestMapper{cache: r.cache, typeLookup: r.typeLookup}
+	enqueueMapped := &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(mapper.Map)}
+
+	if err := ctl.Watch(&source.Kind{Type: &corev1.Secret{}}, enqueueMapped); err != nil {
+		return err
+	}
+
+	serviceCatalogBinding := &unstructured.Unstructured{}
+	serviceCatalogBinding.SetGroupVersionKind(serviceCatalogServiceBindingGVK)
+	if err := ctl.Watch(&source.Kind{Type: serviceCatalogBinding}, enqueueMapped); err != nil {
+		return err
+	}
+
+	serviceCatalogInstance := &unstructured.Unstructured{}
+	serviceCatalogInstance.SetGroupVersionKind(serviceCatalogServiceInstanceGVK)
+	if err := ctl.Watch(&source.Kind{Type: serviceCatalogInstance}, enqueueMapped); err != nil {
+		return err
+	}
+
+	r.cache.watcher = func(gvk schema.GroupVersionKind) error {
+		if gvk == secretGVK || gvk == serviceCatalogServiceBindingGVK || gvk == serviceCatalogServiceInstanceGVK {
+			return nil
+		}
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(gvk)
+		return ctl.Watch(&source.Kind{Type: u}, enqueueMapped)
+	}
+
+	sbrInformer, err := mgr.GetCache().GetInformer(&v1alpha1.ServiceBinding{})
+	if err != nil {
+		return err
+	}
+	sbrInformer.AddEventHandler(newSBRIndexEventHandler(r.cache, r.typeLookup))
+
+	return nil
+}
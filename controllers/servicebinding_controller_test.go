@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/redhat-developer/service-binding-operator/api/v1alpha1"
+)
+
+// listRefusingClient wraps a client.Client and fails the test if List is ever
+// called on it, so tests can assert a code path does not list objects.
+type listRefusingClient struct {
+	client.Client
+	t *testing.T
+}
+
+func (c listRefusingClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	c.t.Fatal("did not expect List to be called")
+	return nil
+}
+
+// readyCondition returns sbr's Ready condition, if any.
+func readyCondition(sbr *v1alpha1.ServiceBinding) (v1alpha1.Condition, bool) {
+	for _, c := range sbr.Status.Conditions {
+		if c.Type == v1alpha1.BindingReady {
+			return c, true
+		}
+	}
+	return v1alpha1.Condition{}, false
+}
+
+// TestReconcileSetsReadyTrueOnSuccess verifies that a successful Reconcile
+// reports Ready=True, including clearing a stale Ready=False condition left
+// over from a since-resolved cyclic field reference.
+func TestReconcileSetsReadyTrueOnSuccess(t *testing.T) {
+	sbr := &v1alpha1.ServiceBinding{
+		Status: v1alpha1.ServiceBindingStatus{
+			Conditions: []v1alpha1.Condition{{
+				Type:    v1alpha1.BindingReady,
+				Status:  corev1.ConditionFalse,
+				Reason:  "CyclicFieldReference",
+				Message: "stale condition from a cycle that no longer exists",
+			}},
+		},
+	}
+	sbr.Namespace = "ns1"
+	sbr.Name = "sbr1"
+
+	r := newTestReconciler(sbr)
+
+	if _, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "ns1", Name: "sbr1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := &v1alpha1.ServiceBinding{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "ns1", Name: "sbr1"}, got); err != nil {
+		t.Fatalf("getting ServiceBinding: %v", err)
+	}
+
+	condition, ok := readyCondition(got)
+	if !ok {
+		t.Fatal("expected a Ready condition to be set")
+	}
+	if condition.Status != corev1.ConditionTrue {
+		t.Fatalf("expected Ready=True, got %v (reason %q)", condition.Status, condition.Reason)
+	}
+}
+
+// TestReconcileRequeuesOnUnresolvedReference verifies that Reconcile returns
+// an error (so controller-runtime requeues with backoff) when one of sbr's
+// references can't be resolved yet, instead of the failure only being logged
+// and the reference silently dropped from the index forever.
+func TestReconcileRequeuesOnUnresolvedReference(t *testing.T) {
+	sbr := &v1alpha1.ServiceBinding{
+		Spec: v1alpha1.ServiceBindingSpec{
+			// Malformed APIVersion: schema.ParseGroupVersion rejects more than
+			// one "/", so Service.GroupVersionKind fails.
+			Services: []v1alpha1.Service{{APIVersion: "a/b/c", Kind: "Secret", Name: "unresolvable"}},
+		},
+	}
+	sbr.Namespace = "ns1"
+	sbr.Name = "sbr1"
+
+	r := newTestReconciler(sbr)
+
+	if _, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "ns1", Name: "sbr1"}}); err == nil {
+		t.Fatal("expected Reconcile to return an error so the ServiceBinding is requeued")
+	}
+}
+
+// TestDetectCyclicFieldReferenceSkipsListWithoutBindSecretRef verifies that
+// detectCyclicFieldReference returns early, without listing every
+// ServiceBinding in the namespace, when sbr has no bindsecret field
+// reference: a cycle can only start from one, so the common case of an SBR
+// that doesn't use the feature shouldn't pay for an O(#SBRs) List on every
+// Reconcile.
+func TestDetectCyclicFieldReferenceSkipsListWithoutBindSecretRef(t *testing.T) {
+	sbr := &v1alpha1.ServiceBinding{
+		Spec: v1alpha1.ServiceBindingSpec{
+			Mappings: []v1alpha1.Mapping{
+				{Name: "host", Value: "{{ service:db#status.host }}"},
+			},
+		},
+	}
+	sbr.Namespace = "ns1"
+	sbr.Name = "sbr1"
+
+	r := newTestReconciler()
+	r.Client = listRefusingClient{Client: r.Client, t: t}
+
+	if cyclic := r.detectCyclicFieldReference(sbr); cyclic {
+		t.Fatal("expected no cycle to be reported")
+	}
+}
@@ -0,0 +1,38 @@
+// Package log provides the structured logger used across the operator's
+// controllers, adding a Trace level below Debug for high-volume diagnostic
+// output that would otherwise be too noisy to leave at Debug.
+package log
+
+import (
+	"github.com/go-logr/logr"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Log wraps logr.Logger with a Trace level and a WithValues that preserves
+// the wrapper type.
+type Log struct {
+	logr.Logger
+}
+
+// NewLog returns a Log named name, suitable for controllers and other
+// components to log through.
+func NewLog(name string) *Log {
+	return &Log{Logger: logf.Log.WithName(name)}
+}
+
+// WithValues returns a Log that logs msg with the given structured key/value
+// pairs attached to every subsequent call.
+func (l *Log) WithValues(keysAndValues ...interface{}) *Log {
+	return &Log{Logger: l.Logger.WithValues(keysAndValues...)}
+}
+
+// Debug logs msg at the operator's normal diagnostic level.
+func (l *Log) Debug(msg string, keysAndValues ...interface{}) {
+	l.Logger.Info(msg, keysAndValues...)
+}
+
+// Trace logs msg below Debug, for output that's only useful when chasing a
+// specific issue.
+func (l *Log) Trace(msg string, keysAndValues ...interface{}) {
+	l.Logger.V(1).Info(msg, keysAndValues...)
+}